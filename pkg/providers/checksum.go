@@ -0,0 +1,20 @@
+package providers
+
+import "strings"
+
+// parseChecksumManifest finds assetName's digest in a whole-release
+// checksum manifest body, formatted the way GNU coreutils' sha256sum
+// writes one: "<hex>  <filename>" (optionally "*<filename>" in binary
+// mode), one entry per line.
+func parseChecksumManifest(body, assetName string) (string, bool) {
+	for _, line := range strings.Split(body, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if strings.TrimPrefix(fields[1], "*") == assetName {
+			return fields[0], true
+		}
+	}
+	return "", false
+}
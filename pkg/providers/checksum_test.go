@@ -0,0 +1,99 @@
+package providers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marcosnils/bin/pkg/assets"
+)
+
+func TestParseChecksumManifest(t *testing.T) {
+	const manifest = "deadbeef  tool_linux_amd64.tar.gz\n" +
+		"cafebabe *tool_darwin_amd64.tar.gz\n" +
+		"\n" +
+		"not a checksum line\n"
+
+	tests := []struct {
+		name      string
+		assetName string
+		wantHex   string
+		wantOK    bool
+	}{
+		{"plain entry", "tool_linux_amd64.tar.gz", "deadbeef", true},
+		{"binary-mode asterisk is stripped", "tool_darwin_amd64.tar.gz", "cafebabe", true},
+		{"asset not listed", "tool_windows_amd64.zip", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseChecksumManifest(manifest, tt.assetName)
+			if ok != tt.wantOK || got != tt.wantHex {
+				t.Errorf("parseChecksumManifest(_, %q) = (%q, %v), want (%q, %v)", tt.assetName, got, ok, tt.wantHex, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFindExpectedChecksum(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tool.sha256":
+			w.Write([]byte("deadbeef  tool\n"))
+		case "/empty.sha256":
+			w.Write([]byte("   \n"))
+		case "/checksums.txt":
+			w.Write([]byte("cafebabe  manifest-tool\n"))
+		}
+	}))
+	defer srv.Close()
+
+	t.Run("per-asset digest file", func(t *testing.T) {
+		candidates := []*assets.Asset{
+			{Name: "tool", URL: srv.URL + "/tool"},
+			{Name: "tool.sha256", URL: srv.URL + "/tool.sha256"},
+		}
+		digest, ok, err := findExpectedChecksum(candidates, "tool")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !ok || digest != "deadbeef" {
+			t.Fatalf("got (%q, %v), want (\"deadbeef\", true)", digest, ok)
+		}
+	})
+
+	t.Run("empty digest file errors instead of panicking", func(t *testing.T) {
+		candidates := []*assets.Asset{
+			{Name: "empty", URL: srv.URL + "/empty"},
+			{Name: "empty.sha256", URL: srv.URL + "/empty.sha256"},
+		}
+		if _, _, err := findExpectedChecksum(candidates, "empty"); err == nil {
+			t.Fatal("expected an error for an empty checksum file, got nil")
+		}
+	})
+
+	t.Run("whole-release manifest", func(t *testing.T) {
+		candidates := []*assets.Asset{
+			{Name: "manifest-tool", URL: srv.URL + "/manifest-tool"},
+			{Name: "checksums.txt", URL: srv.URL + "/checksums.txt"},
+		}
+		digest, ok, err := findExpectedChecksum(candidates, "manifest-tool")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !ok || digest != "cafebabe" {
+			t.Fatalf("got (%q, %v), want (\"cafebabe\", true)", digest, ok)
+		}
+	})
+
+	t.Run("no checksum asset at all", func(t *testing.T) {
+		candidates := []*assets.Asset{{Name: "tool", URL: srv.URL + "/tool"}}
+		_, ok, err := findExpectedChecksum(candidates, "tool")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ok {
+			t.Fatal("expected ok=false when no checksum asset is present")
+		}
+	})
+}
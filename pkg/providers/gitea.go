@@ -0,0 +1,137 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/marcosnils/bin/pkg/assets"
+)
+
+// giteaHost implements gitHost against the Gitea/Forgejo Releases API
+// (/api/v1/repos/:owner/:repo/releases), for codeberg.org (which runs
+// Forgejo) and for self-hosted Gitea/Forgejo instances configured in the
+// bin config's "hosts" map.
+type giteaHost struct {
+	baseURL   string
+	authToken string
+	owner     string
+	repo      string
+	client    *http.Client
+}
+
+// newGiteaHost builds the Gitea/Forgejo backend for owner/repo at u's
+// host, authenticating with GITEA_AUTH_TOKEN when set and wrapping
+// requests with the on-disk ETag cache per cfg.
+func newGiteaHost(u *url.URL, owner, repo string, cfg Config) (*giteaHost, error) {
+	return &giteaHost{
+		baseURL:   fmt.Sprintf("%s://%s/api/v1", u.Scheme, u.Host),
+		authToken: authTokenFor(hostKindGitea),
+		owner:     owner,
+		repo:      repo,
+		client:    cachedHTTPClient(nil, cfg),
+	}, nil
+}
+
+type giteaRelease struct {
+	TagName    string `json:"tag_name"`
+	HTMLURL    string `json:"html_url"`
+	Prerelease bool   `json:"prerelease"`
+	Draft      bool   `json:"draft"`
+	Assets     []struct {
+		Name               string `json:"name"`
+		BrowserDownloadURL string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+func (g *giteaHost) LatestRelease(ctx context.Context) (*hostRelease, error) {
+	releases, err := g.ListReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range releases {
+		if !r.Draft && !r.Prerelease {
+			return r, nil
+		}
+	}
+	if len(releases) > 0 {
+		return releases[0], nil
+	}
+	return nil, errNoReleases
+}
+
+func (g *giteaHost) ReleaseByTag(ctx context.Context, tag string) (*hostRelease, error) {
+	var r giteaRelease
+	if err := g.get(ctx, fmt.Sprintf("/repos/%s/%s/releases/tags/%s", g.owner, g.repo, url.PathEscape(tag)), &r); err != nil {
+		return nil, err
+	}
+	return toGiteaHostRelease(r), nil
+}
+
+// ListReleases walks every page of releases, newest first - the Gitea API
+// already returns releases newest-first by default.
+func (g *giteaHost) ListReleases(ctx context.Context) ([]*hostRelease, error) {
+	var all []*hostRelease
+	page := 1
+	for {
+		var releases []giteaRelease
+		path := fmt.Sprintf("/repos/%s/%s/releases?limit=%d&page=%d", g.owner, g.repo, releasesPerPage, page)
+		if err := g.get(ctx, path, &releases); err != nil {
+			return nil, err
+		}
+		if len(releases) == 0 {
+			break
+		}
+		for _, r := range releases {
+			all = append(all, toGiteaHostRelease(r))
+		}
+		if len(releases) < releasesPerPage {
+			break
+		}
+		page++
+	}
+	return all, nil
+}
+
+func toGiteaHostRelease(r giteaRelease) *hostRelease {
+	as := make([]*assets.Asset, 0, len(r.Assets))
+	for _, a := range r.Assets {
+		as = append(as, &assets.Asset{Name: a.Name, URL: a.BrowserDownloadURL})
+	}
+	return &hostRelease{
+		TagName:    r.TagName,
+		HTMLURL:    r.HTMLURL,
+		Prerelease: r.Prerelease,
+		Draft:      r.Draft,
+		Assets:     as,
+	}
+}
+
+// get performs an authenticated GET against the Gitea/Forgejo API and
+// decodes the JSON response body into out.
+func (g *giteaHost) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	if g.authToken != "" {
+		req.Header.Set("Authorization", "token "+g.authToken)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errNoReleases
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("gitea API request to %s failed with status %d", path, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,246 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/marcosnils/bin/pkg/assets"
+)
+
+// hostRelease is the provider-agnostic view of a single release (or, for
+// hosts without a release API, a tag) that a gitHost implementation
+// translates its forge's API response into, so hostProvider never has to
+// know whether it's talking to GitHub, GitLab or Gitea.
+type hostRelease struct {
+	TagName    string
+	HTMLURL    string
+	Prerelease bool
+	Draft      bool
+	Assets     []*assets.Asset
+}
+
+// gitHost is the API surface every supported forge backend implements.
+// hostProvider drives Fetch/GetLatestVersion purely through this interface,
+// so adding a new forge only means adding a new gitHost, not touching the
+// release/asset resolution logic.
+type gitHost interface {
+	LatestRelease(ctx context.Context) (*hostRelease, error)
+	ReleaseByTag(ctx context.Context, tag string) (*hostRelease, error)
+	ListReleases(ctx context.Context) ([]*hostRelease, error)
+}
+
+// hostKind identifies which backend owns a URL's host.
+type hostKind string
+
+const (
+	hostKindGitHub hostKind = "github"
+	hostKindGitLab hostKind = "gitlab"
+	hostKindGitea  hostKind = "gitea"
+)
+
+// defaultHostKinds maps the well-known SaaS hostnames to their kind.
+// Self-hosted GitLab/Gitea/Forgejo instances are resolved through the bin
+// config's "hosts" map instead, since their hostname can be anything.
+var defaultHostKinds = map[string]hostKind{
+	"github.com":   hostKindGitHub,
+	"gitlab.com":   hostKindGitLab,
+	"codeberg.org": hostKindGitea,
+}
+
+// hostKindFor resolves which backend owns host, consulting the
+// user-configured overrides before the well-known defaults.
+func hostKindFor(host string, overrides map[string]string) (hostKind, error) {
+	if k, ok := overrides[host]; ok {
+		return hostKind(k), nil
+	}
+	if k, ok := defaultHostKinds[host]; ok {
+		return k, nil
+	}
+	return "", fmt.Errorf("don't know how to handle git host %q; add it to the bin config's \"hosts\" map", host)
+}
+
+// authTokenFor returns the auth token for kind, read from the same
+// per-host env var convention GITHUB_AUTH_TOKEN already established.
+func authTokenFor(kind hostKind) string {
+	switch kind {
+	case hostKindGitLab:
+		return os.Getenv("GITLAB_AUTH_TOKEN")
+	case hostKindGitea:
+		return os.Getenv("GITEA_AUTH_TOKEN")
+	default:
+		return os.Getenv("GITHUB_AUTH_TOKEN")
+	}
+}
+
+// splitOwnerRepo extracts "owner", "repo", an optional release tag, and an
+// optional semver constraint out of a forge URL path. GitHub, GitLab and
+// Gitea/Forgejo all share the same /owner/repo[/releases/tag/<tag>] layout
+// for the cases bin cares about. A trailing "@<constraint>" on repo, e.g.
+// "owner/repo@~1.2" or "owner/repo@>=1.4.0,<2.0.0", pins Fetch and
+// GetLatestVersion to the highest release matching that range.
+func splitOwnerRepo(u *url.URL) (owner, repo, tag, constraint string, err error) {
+	s := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(s) < 2 {
+		return "", "", "", "", fmt.Errorf("error parsing %s, can't find owner and repo", u.String())
+	}
+	owner, repo = s[0], s[1]
+
+	if i := strings.LastIndex(repo, "@"); i >= 0 {
+		constraint = repo[i+1:]
+		repo = repo[:i]
+	}
+
+	if strings.Contains(u.Path, "/releases/") {
+		// For release and download URL's, the path is usually
+		// /releases/tag/v0.1 or /releases/download/v0.1.
+		for i, p := range s {
+			if p == "releases" && i+2 <= len(s) {
+				tag = strings.Join(s[i+2:], "/")
+			}
+		}
+	}
+	return owner, repo, tag, constraint, nil
+}
+
+// Config carries the per-repo bin config knobs that apply regardless of
+// which gitHost backend ends up serving a URL. It's the exported surface
+// New expects the bin config file and CLI flags to populate; any field
+// left at its zero value falls back to the BIN_* env vars in
+// withEnvDefaults.
+type Config struct {
+	HostOverrides      map[string]string
+	PubKey             string
+	IncludePrereleases bool
+	GoInstall          bool
+	NamingScheme       string
+	// Constraint is a semver range, e.g. "~1.2" or ">=1.4.0,<2.0.0", used
+	// when the URL itself doesn't carry a "@<constraint>" suffix.
+	Constraint string
+	// NoCache disables the on-disk ETag cache for release metadata
+	// requests, the --no-cache flag's knob.
+	NoCache bool
+	// CacheTTL lets a cached response short-circuit the network entirely
+	// for this long before being revalidated with If-None-Match. Zero
+	// means always revalidate.
+	CacheTTL time.Duration
+}
+
+// withEnvDefaults fills any zero-value field in cfg from the BIN_* env vars,
+// the same per-host-override convention authTokenFor's GITHUB_AUTH_TOKEN &
+// friends already establish, since this package has no config/CLI layer of
+// its own to construct Config from. Anything the caller already set
+// explicitly (e.g. a per-repo bin config override) takes precedence.
+func (cfg Config) withEnvDefaults() Config {
+	if cfg.PubKey == "" {
+		cfg.PubKey = os.Getenv("BIN_PUBKEY")
+	}
+	if cfg.NamingScheme == "" {
+		cfg.NamingScheme = os.Getenv("BIN_NAMING_SCHEME")
+	}
+	if cfg.Constraint == "" {
+		cfg.Constraint = os.Getenv("BIN_CONSTRAINT")
+	}
+	if !cfg.GoInstall {
+		cfg.GoInstall = os.Getenv("BIN_GO_INSTALL") == "true"
+	}
+	if !cfg.IncludePrereleases {
+		cfg.IncludePrereleases = os.Getenv("BIN_INCLUDE_PRERELEASES") == "true"
+	}
+	if !cfg.NoCache {
+		cfg.NoCache = os.Getenv("BIN_NO_CACHE") == "true"
+	}
+	if cfg.CacheTTL == 0 {
+		if ttl, err := time.ParseDuration(os.Getenv("BIN_CACHE_TTL")); err == nil {
+			cfg.CacheTTL = ttl
+		}
+	}
+	if cfg.HostOverrides == nil {
+		cfg.HostOverrides = hostOverridesFromEnv(os.Getenv("BIN_HOSTS"))
+	}
+	return cfg
+}
+
+// hostOverridesFromEnv parses BIN_HOSTS, a comma-separated list of
+// "host=kind" pairs (e.g. "git.example.com=gitlab,forge.example.com=gitea"),
+// into the same map shape as cfg.HostOverrides.
+func hostOverridesFromEnv(s string) map[string]string {
+	if s == "" {
+		return nil
+	}
+	overrides := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		overrides[kv[0]] = kv[1]
+	}
+	return overrides
+}
+
+// cachedHTTPClient builds an http.Client whose transport is wrapped with
+// the on-disk ETag cache, for backends that need a plain *http.Client
+// rather than bare RoundTripper access (e.g. GitHub's oauth2 client).
+func cachedHTTPClient(base *http.Client, cfg Config) *http.Client {
+	if base == nil {
+		base = &http.Client{}
+	}
+	base.Transport = newCachingTransport(base.Transport, cfg.CacheTTL, cfg.NoCache)
+	return base
+}
+
+// New dispatches u to the right gitHost backend based on its hostname -
+// github.com, gitlab.com, codeberg.org, or a self-hosted GitLab/Gitea
+// instance configured in cfg.HostOverrides - and wraps it in the shared
+// hostProvider so Fetch/GetLatestVersion behave the same way regardless of
+// which forge is actually serving the release. This is the entry point the
+// bin config/CLI layer is expected to call with a populated Config; cfg's
+// zero-value fields still fall back to the BIN_* env vars for callers that
+// don't wire one through yet.
+func New(u *url.URL, cfg Config) (Provider, error) {
+	cfg = cfg.withEnvDefaults()
+
+	kind, err := hostKindFor(u.Host, cfg.HostOverrides)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, repo, tag, constraintStr, err := splitOwnerRepo(u)
+	if err != nil {
+		return nil, err
+	}
+	if constraintStr == "" {
+		constraintStr = cfg.Constraint
+	}
+
+	var constraint *semver.Constraints
+	if constraintStr != "" {
+		constraint, err = semver.NewConstraint(constraintStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %w", constraintStr, err)
+		}
+	}
+
+	var host gitHost
+	switch kind {
+	case hostKindGitHub:
+		host, err = newGitHubHost(owner, repo, cfg)
+	case hostKindGitLab:
+		host, err = newGitLabHost(u, owner, repo, cfg)
+	case hostKindGitea:
+		host, err = newGiteaHost(u, owner, repo, cfg)
+	default:
+		err = fmt.Errorf("unsupported git host kind %q", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newHostProvider(host, kind, u, owner, repo, tag, constraint, cfg), nil
+}
@@ -2,134 +2,131 @@ package providers
 
 import (
 	"context"
-	"crypto/sha256"
-	"fmt"
 	"net/http"
 	"net/url"
-	"os"
-	"strings"
 
-	"github.com/apex/log"
 	"github.com/google/go-github/v31/github"
-	"github.com/marcosnils/bin/pkg/assets"
 	"golang.org/x/oauth2"
+
+	"github.com/marcosnils/bin/pkg/assets"
 )
 
-type gitHub struct {
-	url    *url.URL
+// gitHubHost implements gitHost against the GitHub REST API. It's the
+// default backend for github.com and carries no state beyond the API
+// client and the repo it was built for - everything else (checksum
+// verification, naming schemes, version constraints) lives in the shared
+// hostProvider.
+type gitHubHost struct {
 	client *github.Client
 	owner  string
 	repo   string
-	tag    string
 }
 
-func (g *gitHub) Fetch() (*File, error) {
-	var release *github.RepositoryRelease
-
-	// If we have a tag, let's fetch from there
-	var err error
-	if len(g.tag) > 0 {
-		log.Infof("Getting %s release for %s/%s", g.tag, g.owner, g.repo)
-		release, _, err = g.client.Repositories.GetReleaseByTag(context.TODO(), g.owner, g.repo, g.tag)
-	} else {
-		// TODO handle case when repo doesn't have releases?
-		log.Infof("Getting latest release for %s/%s", g.owner, g.repo)
-		release, err = g.getAnyLatestRelease(context.TODO())
-	}
-
-	if err != nil {
-		return nil, err
-	}
-
-	candidates := []*assets.Asset{}
-	for _, a := range release.Assets {
-		candidates = append(candidates, &assets.Asset{Name: a.GetName(), URL: a.GetBrowserDownloadURL()})
-	}
-	gf, err := assets.FilterAssets(g.repo, candidates)
-	if err != nil {
-		return nil, err
-	}
-
-	name, outputFile, err := assets.ProcessURL(gf)
-	if err != nil {
-		return nil, err
-	}
-
-	version := release.GetTagName()
-
-	// TODO calculate file hash. Not sure if we can / should do it here
-	// since we don't want to read the file unnecesarily. Additionally, sometimes
-	// releases have .sha256 files, so it'd be nice to check for those also
-	f := &File{Data: outputFile, Name: assets.SanitizeName(name, version), Hash: sha256.New(), Version: version}
-
-	return f, nil
-}
-
-// GetLatestVersion checks the latest repo release and
-// returns the corresponding name and url to fetch the version
-func (g *gitHub) GetLatestVersion() (string, string, error) {
-	log.Debugf("Getting latest release for %s/%s", g.owner, g.repo)
-	release, err := g.getAnyLatestRelease(context.TODO())
-	if err != nil {
-		return "", "", err
+// newGitHubHost builds the GitHub backend for owner/repo, authenticating
+// with GITHUB_AUTH_TOKEN when set and wrapping the transport with the
+// on-disk ETag cache per cfg.
+func newGitHubHost(owner, repo string, cfg Config) (*gitHubHost, error) {
+	token := authTokenFor(hostKindGitHub)
+	var tc *http.Client
+	if token != "" {
+		tc = oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: token},
+		))
 	}
-
-	return release.GetTagName(), release.GetHTMLURL(), nil
+	tc = cachedHTTPClient(tc, cfg)
+	return &gitHubHost{client: github.NewClient(tc), owner: owner, repo: repo}, nil
 }
 
-func (g *gitHub) getAnyLatestRelease(ctx context.Context) (*github.RepositoryRelease, error) {
+func (g *gitHubHost) LatestRelease(ctx context.Context) (*hostRelease, error) {
 	release, _, err := g.client.Repositories.GetLatestRelease(ctx, g.owner, g.repo)
 	if err != nil {
 		// If the error is that no latest release was found, it could be that there are only pre-releases
 		if ghErrResp, ok := err.(*github.ErrorResponse); ok && ghErrResp.Response.StatusCode == http.StatusNotFound {
-			// Get the first release returned by ListReleases
-			releases, _, listErr := g.client.Repositories.ListReleases(ctx, g.owner, g.repo, &github.ListOptions{PerPage: 1})
+			releases, listErr := g.ListReleases(ctx)
 			if listErr != nil {
 				return nil, listErr
 			}
 			if len(releases) > 0 {
 				return releases[0], nil
 			}
+			return nil, errNoReleases
 		}
 
 		// Return original 404/StatusNotFound error from GetLatestRelease
 		return nil, err
 	}
-	return release, err
+	return toHostRelease(release), nil
 }
 
-func (g *gitHub) GetID() string {
-	return "github"
+func (g *gitHubHost) ReleaseByTag(ctx context.Context, tag string) (*hostRelease, error) {
+	release, _, err := g.client.Repositories.GetReleaseByTag(ctx, g.owner, g.repo, tag)
+	if err != nil {
+		return nil, err
+	}
+	return toHostRelease(release), nil
 }
 
-func newGitHub(u *url.URL) (Provider, error) {
-	s := strings.Split(u.Path, "/")
-	if len(s) < 2 {
-		return nil, fmt.Errorf("Error parsing Github URL %s, can't find owner and repo", u.String())
+// ListReleases walks every page of releases for the repo, newest first, so
+// callers don't miss versions that GitHub's "latest" endpoint skips (e.g.
+// repos that only ever publish prereleases).
+func (g *gitHubHost) ListReleases(ctx context.Context) ([]*hostRelease, error) {
+	var all []*hostRelease
+	opt := &github.ListOptions{PerPage: releasesPerPage}
+	for {
+		releases, resp, err := g.client.Repositories.ListReleases(ctx, g.owner, g.repo, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range releases {
+			all = append(all, toHostRelease(r))
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
 	}
+	return all, nil
+}
 
-	// it's a specific releases URL
-	var tag string
-	if strings.Contains(u.Path, "/releases/") {
-		// For release and download URL's, the
-		// path is usually /releases/tag/v0.1
-		// or /releases/download/v0.1.
-		ps := strings.Split(u.Path, "/")
-		for i, p := range ps {
-			if p == "releases" {
-				tag = strings.Join(ps[i+2:], "/")
-			}
+// ListTags walks every page of tags for the repo, used by the go-install
+// fallback for repos that have tags but no GitHub Releases at all.
+func (g *gitHubHost) ListTags(ctx context.Context) ([]string, error) {
+	var all []string
+	opt := &github.ListOptions{PerPage: releasesPerPage}
+	for {
+		tags, resp, err := g.client.Repositories.ListTags(ctx, g.owner, g.repo, opt)
+		if err != nil {
+			return nil, err
 		}
-
+		for _, t := range tags {
+			all = append(all, t.GetName())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
 	}
+	return all, nil
+}
 
-	token := os.Getenv("GITHUB_AUTH_TOKEN")
-	var tc *http.Client
-	if token != "" {
-		tc = oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(
-			&oauth2.Token{AccessToken: token},
-		))
+func toHostRelease(r *github.RepositoryRelease) *hostRelease {
+	as := make([]*assets.Asset, 0, len(r.Assets))
+	for _, a := range r.Assets {
+		as = append(as, &assets.Asset{Name: a.GetName(), URL: a.GetBrowserDownloadURL()})
 	}
-	client := github.NewClient(tc)
-	return &gitHub{url: u, client: client, owner: s[1], repo: s[2], tag: tag}, nil
+	return &hostRelease{
+		TagName:    r.GetTagName(),
+		HTMLURL:    r.GetHTMLURL(),
+		Prerelease: r.GetPrerelease(),
+		Draft:      r.GetDraft(),
+		Assets:     as,
+	}
+}
+
+// newGitHub builds a github.com provider for u, the entry point the bin
+// config's provider registry uses for that host. Kept as a thin wrapper
+// around New so repos not pinned to a config override still "just work"
+// the way they always have.
+func newGitHub(u *url.URL, cfg Config) (Provider, error) {
+	return New(u, cfg)
 }
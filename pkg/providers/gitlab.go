@@ -0,0 +1,135 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/marcosnils/bin/pkg/assets"
+)
+
+// gitLabHost implements gitHost against the GitLab Releases API
+// (/api/v4/projects/:id/releases), for gitlab.com and self-hosted GitLab
+// instances configured in the bin config's "hosts" map.
+type gitLabHost struct {
+	baseURL   string
+	webURL    string // scheme://host/owner/repo, for building HTMLURL
+	authToken string
+	project   string // URL-encoded "owner/repo" path used as the :id
+	client    *http.Client
+}
+
+// newGitLabHost builds the GitLab backend for owner/repo at u's host,
+// authenticating with GITLAB_AUTH_TOKEN when set and wrapping requests
+// with the on-disk ETag cache per cfg.
+func newGitLabHost(u *url.URL, owner, repo string, cfg Config) (*gitLabHost, error) {
+	return &gitLabHost{
+		baseURL:   fmt.Sprintf("%s://%s/api/v4", u.Scheme, u.Host),
+		webURL:    fmt.Sprintf("%s://%s/%s/%s", u.Scheme, u.Host, owner, repo),
+		authToken: authTokenFor(hostKindGitLab),
+		project:   url.QueryEscape(owner + "/" + repo),
+		client:    cachedHTTPClient(nil, cfg),
+	}, nil
+}
+
+type gitlabRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  struct {
+		Links []struct {
+			Name           string `json:"name"`
+			DirectAssetURL string `json:"direct_asset_url"`
+			URL            string `json:"url"`
+		} `json:"links"`
+	} `json:"assets"`
+	UpcomingRelease bool `json:"upcoming_release"`
+}
+
+func (g *gitLabHost) LatestRelease(ctx context.Context) (*hostRelease, error) {
+	releases, err := g.ListReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(releases) == 0 {
+		return nil, errNoReleases
+	}
+	return releases[0], nil
+}
+
+func (g *gitLabHost) ReleaseByTag(ctx context.Context, tag string) (*hostRelease, error) {
+	var r gitlabRelease
+	if err := g.get(ctx, fmt.Sprintf("/projects/%s/releases/%s", g.project, url.PathEscape(tag)), &r); err != nil {
+		return nil, err
+	}
+	return g.toHostRelease(r), nil
+}
+
+// ListReleases walks every page of releases, newest first - GitLab
+// releases are already returned newest-first by default.
+func (g *gitLabHost) ListReleases(ctx context.Context) ([]*hostRelease, error) {
+	var all []*hostRelease
+	page := 1
+	for {
+		var releases []gitlabRelease
+		path := fmt.Sprintf("/projects/%s/releases?per_page=%d&page=%d", g.project, releasesPerPage, page)
+		if err := g.get(ctx, path, &releases); err != nil {
+			return nil, err
+		}
+		if len(releases) == 0 {
+			break
+		}
+		for _, r := range releases {
+			all = append(all, g.toHostRelease(r))
+		}
+		if len(releases) < releasesPerPage {
+			break
+		}
+		page++
+	}
+	return all, nil
+}
+
+func (g *gitLabHost) toHostRelease(r gitlabRelease) *hostRelease {
+	as := make([]*assets.Asset, 0, len(r.Assets.Links))
+	for _, l := range r.Assets.Links {
+		u := l.DirectAssetURL
+		if u == "" {
+			u = l.URL
+		}
+		as = append(as, &assets.Asset{Name: l.Name, URL: u})
+	}
+	return &hostRelease{
+		TagName:    r.TagName,
+		HTMLURL:    fmt.Sprintf("%s/-/releases/%s", g.webURL, r.TagName),
+		Prerelease: r.UpcomingRelease,
+		Assets:     as,
+	}
+}
+
+// get performs an authenticated GET against the GitLab API and decodes the
+// JSON response body into out.
+func (g *gitLabHost) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	if g.authToken != "" {
+		req.Header.Set("PRIVATE-TOKEN", g.authToken)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errNoReleases
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("gitlab API request to %s failed with status %d", path, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,564 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/apex/log"
+	"github.com/marcosnils/bin/pkg/assets"
+)
+
+// releasesPerPage is the page size used when walking every release (or tag)
+// for a repo, e.g. to honor a version constraint or to look past
+// prereleases on page 1. Every supported forge caps this at 100.
+const releasesPerPage = 100
+
+// checksumSuffixes are the conventional names/extensions release pipelines
+// use to publish digests for their binary assets, checked in order.
+var checksumSuffixes = []string{".sha256", ".sha256sum"}
+
+// checksumManifests are whole-release digest files that list every asset's
+// hash on its own line, usually as "<hex>  <filename>".
+var checksumManifests = []string{"checksums.txt", "SHA256SUMS", "SHA256SUMS.txt"}
+
+// errNoReleases means the repo has no releases listed at all, as opposed
+// to a 404 that's just hiding prereleases behind pagination. The GitLab and
+// Gitea backends also return it for any 404 from their API, including a
+// tag with no release.
+var errNoReleases = errors.New("no releases found")
+
+// defaultNamingScheme is used when the bin config doesn't override it for a
+// repo, matching the convention goreleaser and friends already publish
+// assets under. It includes Version because that's what goreleaser's own
+// default template does; a scheme without it would almost never match a
+// real release and the fuzzy filter would run on every Fetch anyway.
+const defaultNamingScheme = "{{.ProductName}}_{{.Version}}_{{.GOOS}}_{{.GOARCH}}{{.EXT}}"
+
+// nameTemplateData is what a naming-scheme template is expanded with.
+type nameTemplateData struct {
+	ProductName string
+	GOOS        string
+	GOARCH      string
+	Version     string
+	EXT         string
+}
+
+// tagLister is implemented by gitHost backends that can also enumerate raw
+// Git tags, used by the go-install fallback for repos with no releases at
+// all. Only gitHubHost implements it today, since "go install" only makes
+// sense for github.com import paths.
+type tagLister interface {
+	ListTags(ctx context.Context) ([]string, error)
+}
+
+// hostProvider implements Provider on top of any gitHost backend, so the
+// release/asset resolution, checksum and signature verification, naming
+// scheme and version-constraint logic only has to be written once and is
+// shared by every forge bin supports.
+type hostProvider struct {
+	host gitHost
+	kind hostKind
+	url  *url.URL
+
+	owner string
+	repo  string
+	tag   string
+
+	// pubKey is an optional armored/raw public key, configured per-repo in
+	// the bin config, used to verify a detached signature or cosign bundle
+	// shipped alongside the release asset.
+	pubKey string
+	// constraint restricts latestRelease to tags matching a semver range,
+	// e.g. "~1.2" or ">=1.4.0,<2.0.0", parsed from the URL's "@<constraint>"
+	// suffix or the bin config.
+	constraint *semver.Constraints
+	// includePrereleases allows a constraint match to land on a prerelease
+	// tag instead of only stable releases.
+	includePrereleases bool
+	// goInstall gates the "go install owner/repo/...@tag" fallback used
+	// when a repo has no releases at all, only Git tags. Only meaningful
+	// for the GitHub backend.
+	goInstall bool
+	// namingScheme is a text/template expanded against nameTemplateData and
+	// matched exactly against release asset names before the fuzzy filter
+	// in assets.FilterAssets runs, letting users escape-hatch repos whose
+	// naming defeats the heuristic.
+	namingScheme string
+}
+
+// newHostProvider wraps host in the shared Fetch/GetLatestVersion logic.
+// constraint is already-parsed from the URL's "@<constraint>" suffix or
+// cfg.Constraint, since both need the owner/repo split to happen first.
+func newHostProvider(host gitHost, kind hostKind, u *url.URL, owner, repo, tag string, constraint *semver.Constraints, cfg Config) *hostProvider {
+	namingScheme := cfg.NamingScheme
+	if namingScheme == "" {
+		namingScheme = defaultNamingScheme
+	}
+
+	return &hostProvider{
+		host:               host,
+		kind:               kind,
+		url:                u,
+		owner:              owner,
+		repo:               repo,
+		tag:                tag,
+		pubKey:             cfg.PubKey,
+		constraint:         constraint,
+		includePrereleases: cfg.IncludePrereleases,
+		goInstall:          cfg.GoInstall,
+		namingScheme:       namingScheme,
+	}
+}
+
+func (p *hostProvider) GetID() string {
+	return string(p.kind)
+}
+
+func (p *hostProvider) Fetch() (*File, error) {
+	ctx := context.TODO()
+	var release *hostRelease
+
+	var err error
+	if len(p.tag) > 0 {
+		log.Infof("Getting %s release for %s/%s", p.tag, p.owner, p.repo)
+		release, err = p.host.ReleaseByTag(ctx, p.tag)
+	} else {
+		log.Infof("Getting latest release for %s/%s", p.owner, p.repo)
+		release, err = p.latestRelease(ctx)
+		if errors.Is(err, errNoReleases) {
+			return p.fetchFromTag(ctx)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	gf := p.resolveNamedAsset(release.Assets, release.TagName)
+	if gf == nil {
+		gf, err = assets.FilterAssets(p.repo, release.Assets)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Checksums and signatures a release publishes are computed over the
+	// asset exactly as uploaded (e.g. the .tar.gz), not over the binary
+	// assets.ProcessURL extracts from it - download the raw asset once up
+	// front so verification runs against the bytes it actually signs.
+	rawData, err := downloadAsset(gf.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if expected, ok, err := findExpectedChecksum(release.Assets, gf.Name); err != nil {
+		return nil, err
+	} else if ok {
+		sum := sha256.Sum256(rawData)
+		if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, expected) {
+			return nil, fmt.Errorf("checksum mismatch for %s: expected %s, got %s", gf.Name, expected, got)
+		}
+		log.Infof("Verified sha256 checksum for %s", gf.Name)
+	}
+
+	if err := p.verifySignature(release.Assets, gf.Name, rawData); err != nil {
+		return nil, err
+	}
+
+	name, outputFile, err := assets.ProcessURL(gf)
+	if err != nil {
+		return nil, err
+	}
+
+	version := release.TagName
+
+	data, err := ioutil.ReadAll(outputFile)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{Data: bytes.NewReader(data), Name: assets.SanitizeName(name, version), Hash: sha256.New(), Version: version}
+
+	return f, nil
+}
+
+// GetLatestVersion checks the latest repo release and returns the
+// corresponding name and url to fetch the version.
+func (p *hostProvider) GetLatestVersion() (string, string, error) {
+	log.Debugf("Getting latest release for %s/%s", p.owner, p.repo)
+	release, err := p.latestRelease(context.TODO())
+	if errors.Is(err, errNoReleases) {
+		return p.latestVersionFromTag(context.TODO())
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	return release.TagName, release.HTMLURL, nil
+}
+
+// latestVersionFromTag mirrors fetchFromTag's tag resolution for repos with
+// no releases at all (common for Go tools that only tag their source), so
+// `bin update` can report a version for them instead of erroring on every
+// check. Only supported against the GitHub backend today, same as
+// fetchFromTag.
+func (p *hostProvider) latestVersionFromTag(ctx context.Context) (string, string, error) {
+	lister, ok := p.host.(tagLister)
+	if !ok {
+		return "", "", fmt.Errorf("%s/%s has no releases and the %s backend can't fall back to tags", p.owner, p.repo, p.kind)
+	}
+
+	tag, err := highestSemverTag(ctx, lister)
+	if err != nil {
+		return "", "", err
+	}
+
+	return tag, fmt.Sprintf("https://github.com/%s/%s/releases/tag/%s", p.owner, p.repo, tag), nil
+}
+
+// latestRelease picks the release to use when no specific tag was
+// requested: the constrained highest match when a constraint is configured,
+// otherwise whatever the host considers latest.
+func (p *hostProvider) latestRelease(ctx context.Context) (*hostRelease, error) {
+	if p.constraint != nil {
+		return p.constrainedRelease(ctx)
+	}
+	return p.host.LatestRelease(ctx)
+}
+
+// constrainedRelease walks every release and returns the highest one that
+// satisfies p.constraint, respects p.includePrereleases, and has at least
+// one asset the filter can resolve. Releases with no usable assets are
+// skipped rather than failing the whole lookup.
+func (p *hostProvider) constrainedRelease(ctx context.Context) (*hostRelease, error) {
+	releases, err := p.host.ListReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *hostRelease
+	var bestVersion *semver.Version
+	for _, r := range releases {
+		if r.Draft {
+			continue
+		}
+		if r.Prerelease && !p.includePrereleases {
+			continue
+		}
+
+		v, err := semver.NewVersion(r.TagName)
+		if err != nil {
+			continue
+		}
+		if !p.constraint.Check(v) {
+			continue
+		}
+		if bestVersion != nil && v.Compare(bestVersion) <= 0 {
+			continue
+		}
+		if _, err := assets.FilterAssets(p.repo, r.Assets); err != nil {
+			continue
+		}
+
+		best, bestVersion = r, v
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("no release of %s/%s satisfies constraint %s", p.owner, p.repo, p.constraint)
+	}
+	return best, nil
+}
+
+// fetchFromTag handles repos with no releases at all (common for Go tools
+// that only tag their source): it resolves the highest semver tag and, if
+// goInstall is configured, builds that tag locally instead of downloading a
+// prebuilt asset. Only supported against the GitHub backend today.
+func (p *hostProvider) fetchFromTag(ctx context.Context) (*File, error) {
+	lister, ok := p.host.(tagLister)
+	if !ok {
+		return nil, fmt.Errorf("%s/%s has no releases and the %s backend can't fall back to tags", p.owner, p.repo, p.kind)
+	}
+
+	tag, err := highestSemverTag(ctx, lister)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("%s/%s has no releases, falling back to tag %s", p.owner, p.repo, tag)
+
+	if !p.goInstall {
+		return nil, fmt.Errorf("%s/%s has no releases and no prebuilt asset; enable 'go-install' in the bin config to build %s from source", p.owner, p.repo, tag)
+	}
+
+	return goInstallTag(p.owner, p.repo, tag)
+}
+
+// highestSemverTag walks every tag a tagLister reports and picks the
+// highest one that parses as semver, the same ref-to-version resolution
+// the Go module proxy does for repos with tagged-but-unreleased versions.
+func highestSemverTag(ctx context.Context, lister tagLister) (string, error) {
+	tags, err := lister.ListTags(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var best string
+	var bestVersion *semver.Version
+	for _, t := range tags {
+		v, err := semver.NewVersion(t)
+		if err != nil {
+			continue
+		}
+		if bestVersion == nil || v.Compare(bestVersion) > 0 {
+			best, bestVersion = t, v
+		}
+	}
+
+	if best == "" {
+		return "", errors.New("no semver tags found")
+	}
+	return best, nil
+}
+
+// goInstallTag builds owner/repo@tag with the local Go toolchain into a
+// scratch GOBIN and reads the resulting binary back, equivalent to the user
+// running `go install github.com/owner/repo/...@tag` by hand.
+func goInstallTag(owner, repo, tag string) (*File, error) {
+	target := fmt.Sprintf("github.com/%s/%s/...@%s", owner, repo, tag)
+
+	gobin, err := ioutil.TempDir("", "bin-go-install-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(gobin)
+
+	cmd := exec.Command("go", "install", target)
+	cmd.Env = append(os.Environ(), "GOBIN="+gobin)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("go install %s: %w\n%s", target, err, out)
+	}
+
+	entries, err := ioutil.ReadDir(gobin)
+	if err != nil || len(entries) == 0 {
+		return nil, fmt.Errorf("go install %s did not produce a binary", target)
+	}
+
+	binName, err := resolveInstalledBinary(entries, repo)
+	if err != nil {
+		return nil, fmt.Errorf("go install %s: %w", target, err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(gobin, binName))
+	if err != nil {
+		return nil, err
+	}
+
+	return &File{Data: bytes.NewReader(data), Name: assets.SanitizeName(binName, tag), Hash: sha256.New(), Version: tag}, nil
+}
+
+// resolveInstalledBinary picks which GOBIN entry `go install .../...@tag`
+// actually meant for us to install: "go install .../..." commonly builds
+// every command under cmd/, so a repo with more than one main package
+// leaves more than one binary behind. We want the one named after the
+// repo; if GOBIN holds exactly one file regardless of its name, that's
+// unambiguous too.
+func resolveInstalledBinary(entries []os.FileInfo, repo string) (string, error) {
+	want := repo
+	if runtime.GOOS == "windows" {
+		want += ".exe"
+	}
+
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name()
+		if e.Name() == want {
+			return e.Name(), nil
+		}
+	}
+
+	if len(entries) == 1 {
+		return entries[0].Name(), nil
+	}
+
+	return "", fmt.Errorf("produced multiple binaries (%s) and none match the repo name %q; can't tell which one to install", strings.Join(names, ", "), repo)
+}
+
+// findExpectedChecksum looks for a sibling checksum asset for assetName
+// among candidates, either a per-asset digest file (<name>.sha256) or a
+// whole-release manifest (checksums.txt, SHA256SUMS), downloads it and
+// extracts the expected hex digest.
+func findExpectedChecksum(candidates []*assets.Asset, assetName string) (string, bool, error) {
+	for _, suffix := range checksumSuffixes {
+		for _, a := range candidates {
+			if a.Name == assetName+suffix {
+				body, err := downloadAsset(a.URL)
+				if err != nil {
+					return "", false, err
+				}
+				fields := strings.Fields(string(body))
+				if len(fields) == 0 {
+					return "", false, fmt.Errorf("%s is empty, can't read a checksum from it", a.Name)
+				}
+				return fields[0], true, nil
+			}
+		}
+	}
+
+	for _, a := range candidates {
+		for _, manifest := range checksumManifests {
+			if strings.EqualFold(a.Name, manifest) {
+				body, err := downloadAsset(a.URL)
+				if err != nil {
+					return "", false, err
+				}
+				if digest, ok := parseChecksumManifest(string(body), assetName); ok {
+					return digest, true, nil
+				}
+			}
+		}
+	}
+
+	return "", false, nil
+}
+
+// signatureSuffixes maps the sidecar extensions release pipelines publish
+// detached signatures under to the format they need to be verified as.
+// Only OpenPGP (.sig/.asc) is implemented; minisign and cosign bundles are
+// recognized so we can tell the user why they weren't checked instead of
+// silently skipping them.
+var signatureSuffixes = []string{".sig", ".asc"}
+var unsupportedSignatureSuffixes = []string{".minisig", ".cosign.bundle"}
+
+// verifySignature checks for a detached signature shipped alongside
+// assetName and, when one is found and a public key is configured,
+// verifies it against the downloaded data, refusing installation on
+// mismatch.
+func (p *hostProvider) verifySignature(candidates []*assets.Asset, assetName string, data []byte) error {
+	var sigAsset *assets.Asset
+	for _, a := range candidates {
+		for _, suffix := range signatureSuffixes {
+			if a.Name == assetName+suffix {
+				sigAsset = a
+			}
+		}
+	}
+
+	if sigAsset == nil {
+		// Only .sig/.asc are recognized above; an unsupported format
+		// (.minisig, .cosign.bundle) existing in the release isn't itself a
+		// reason to fail - that would break installing releases that ship
+		// them for users who never configured a key to check against. Only
+		// warn once a key is actually configured, since that's the signal
+		// the user wants verification to happen at all.
+		if p.pubKey != "" {
+			for _, a := range candidates {
+				for _, suffix := range unsupportedSignatureSuffixes {
+					if a.Name == assetName+suffix {
+						log.Infof("Found signature %s for %s but bin doesn't support verifying %s signatures yet, skipping", a.Name, assetName, suffix)
+						return nil
+					}
+				}
+			}
+		}
+		return nil
+	}
+
+	if p.pubKey == "" {
+		log.Infof("Found signature %s for %s but no public key is configured, skipping verification", sigAsset.Name, assetName)
+		return nil
+	}
+
+	sig, err := downloadAsset(sigAsset.URL)
+	if err != nil {
+		return err
+	}
+
+	if err := verifyDetachedOpenPGPSignature(data, sig, p.pubKey); err != nil {
+		return fmt.Errorf("signature verification failed for %s: %w", assetName, err)
+	}
+
+	log.Infof("Verified signature for %s", assetName)
+	return nil
+}
+
+// downloadAsset fetches the full body of a release asset URL into memory,
+// used both for the checksum and signature sidecar files and for the raw
+// binary asset that Fetch verifies against them.
+func downloadAsset(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s fetching %s", resp.Status, url)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// resolveNamedAsset expands p.namingScheme against the resolved version and
+// returns the candidate whose name matches exactly, or nil if the scheme is
+// unset, fails to expand, or matches nothing - in which case the caller
+// should fall back to the fuzzy filter.
+func (p *hostProvider) resolveNamedAsset(candidates []*assets.Asset, version string) *assets.Asset {
+	if p.namingScheme == "" {
+		return nil
+	}
+
+	tmpl, err := template.New("naming-scheme").Parse(p.namingScheme)
+	if err != nil {
+		log.Debugf("invalid naming scheme %q for %s/%s: %s", p.namingScheme, p.owner, p.repo, err)
+		return nil
+	}
+
+	var buf bytes.Buffer
+	data := nameTemplateData{
+		ProductName: p.repo,
+		GOOS:        runtime.GOOS,
+		GOARCH:      runtime.GOARCH,
+		Version:     version,
+		EXT:         platformEXT(),
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		log.Debugf("naming scheme %q failed to expand for %s/%s: %s", p.namingScheme, p.owner, p.repo, err)
+		return nil
+	}
+
+	want := buf.String()
+	for _, c := range candidates {
+		if c.Name == want {
+			return c
+		}
+	}
+	return nil
+}
+
+// platformEXT returns the asset suffix expected for the running platform.
+// It only covers the single-binary convention (".exe" on Windows, nothing
+// elsewhere) and deliberately doesn't try to guess an archive extension
+// (".tar.gz", ".zip", ...): those vary per-project by compression choice,
+// not by platform, so a user whose release ships archives should bake the
+// exact suffix into their own naming-scheme override instead of relying on
+// EXT to guess it.
+func platformEXT() string {
+	if runtime.GOOS == "windows" {
+		return ".exe"
+	}
+	return ""
+}
@@ -0,0 +1,151 @@
+package providers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheEntry is what's persisted on disk for one cached URL: the response
+// headers (ETag to revalidate with, but also e.g. Link, which go-github
+// parses for pagination), the body they were attached to, and when we
+// stored it, so a configured TTL can short-circuit the network entirely.
+type cacheEntry struct {
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+// etag returns the ETag header a cached entry was stored with, or "" if
+// the response never sent one.
+func (e *cacheEntry) etag() string {
+	return e.Header.Get("ETag")
+}
+
+// httpCacheDir returns $XDG_CACHE_HOME/bin/http, falling back to
+// ~/.cache/bin/http when XDG_CACHE_HOME isn't set.
+func httpCacheDir() string {
+	base := os.Getenv("XDG_CACHE_HOME")
+	if base == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			base = filepath.Join(home, ".cache")
+		}
+	}
+	return filepath.Join(base, "bin", "http")
+}
+
+// cacheKey hashes a request URL into a filesystem-safe cache file name.
+func cacheKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+// cachingTransport wraps an http.RoundTripper with an on-disk ETag cache
+// keyed by URL, so repeat requests for release metadata - which bin's
+// update check makes for every managed binary, every time - send
+// If-None-Match and short-circuit on 304 instead of paying for a full
+// response. GitHub's REST API returns strong ETags on release endpoints
+// and, per its docs, doesn't count 304s against the rate limit.
+type cachingTransport struct {
+	next     http.RoundTripper
+	dir      string
+	ttl      time.Duration
+	disabled bool
+}
+
+// newCachingTransport wraps next (http.DefaultTransport if nil). ttl <= 0
+// means always revalidate with If-None-Match instead of trusting a cached
+// entry outright; disabled makes it a passthrough, for --no-cache.
+func newCachingTransport(next http.RoundTripper, ttl time.Duration, disabled bool) *cachingTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &cachingTransport{next: next, dir: httpCacheDir(), ttl: ttl, disabled: disabled}
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.disabled || req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+
+	path := filepath.Join(t.dir, cacheKey(req.URL.String()))
+	entry := readCacheEntry(path)
+
+	if entry != nil && t.ttl > 0 && time.Since(entry.StoredAt) < t.ttl {
+		return entry.response(req), nil
+	}
+
+	if entry != nil && entry.etag() != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", entry.etag())
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		resp.Body.Close()
+		entry.StoredAt = time.Now()
+		writeCacheEntry(path, entry)
+		return entry.response(req), nil
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err == nil {
+			writeCacheEntry(path, &cacheEntry{Header: resp.Header, Body: body, StoredAt: time.Now()})
+			resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+	}
+
+	return resp, nil
+}
+
+// response rebuilds a 200 OK http.Response from a cached entry, replaying
+// every header it was stored with - not just ETag - so callers that read
+// other response headers (e.g. go-github deriving pagination from Link)
+// behave the same whether the data came from the network or the cache.
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     e.Header,
+		Body:       ioutil.NopCloser(bytes.NewReader(e.Body)),
+		Request:    req,
+	}
+}
+
+func readCacheEntry(path string) *cacheEntry {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil
+	}
+	return &e
+}
+
+func writeCacheEntry(path string, e *cacheEntry) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	_ = ioutil.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,78 @@
+package providers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCachingTransportRoundTrip(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if inm := r.Header.Get("If-None-Match"); inm == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("release body"))
+	}))
+	defer srv.Close()
+
+	transport := newCachingTransport(http.DefaultTransport, 0, false)
+	transport.dir = t.TempDir()
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "release body" {
+		t.Fatalf("first request body = %q, want %q", body, "release body")
+	}
+	if hits != 1 {
+		t.Fatalf("expected 1 hit to the origin after the first request, got %d", hits)
+	}
+
+	resp, err = client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("second request: %v", err)
+	}
+	body, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "release body" {
+		t.Fatalf("second (304) request body = %q, want %q", body, "release body")
+	}
+	if hits != 2 {
+		t.Fatalf("expected the second request to hit the origin (with If-None-Match) and get a 304, got %d origin hits", hits)
+	}
+}
+
+func TestCachingTransportDisabled(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("body"))
+	}))
+	defer srv.Close()
+
+	transport := newCachingTransport(http.DefaultTransport, 0, true)
+	transport.dir = t.TempDir()
+	client := &http.Client{Transport: transport}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+
+	if hits != 2 {
+		t.Fatalf("disabled cache should hit the origin every time, got %d hits for 2 requests", hits)
+	}
+}
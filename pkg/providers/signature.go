@@ -0,0 +1,27 @@
+package providers
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// verifyDetachedOpenPGPSignature checks data against a detached OpenPGP
+// signature (.sig/.asc) using pubKeyArmor, an ASCII-armored public key.
+// Both armored and binary signature encodings are accepted, since releases
+// publish either depending on the tool that signed them.
+func verifyDetachedOpenPGPSignature(data, sig []byte, pubKeyArmor string) error {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(pubKeyArmor))
+	if err != nil {
+		return fmt.Errorf("parsing configured public key: %w", err)
+	}
+
+	if _, err := openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(data), bytes.NewReader(sig)); err == nil {
+		return nil
+	}
+
+	_, err = openpgp.CheckDetachedSignature(keyring, bytes.NewReader(data), bytes.NewReader(sig))
+	return err
+}